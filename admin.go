@@ -0,0 +1,166 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// otelState tracks the pieces of the tracing setup an admin needs to
+// rebuild the TracerProvider at runtime, e.g. to swap its sampler.
+type otelState struct {
+	mu             sync.Mutex
+	exporter       trace.SpanExporter
+	exporterDesc   string
+	resource       *resource.Resource
+	samplerDesc    string
+	tracerProvider *trace.TracerProvider
+}
+
+var globalOtelState otelState
+
+func (s *otelState) set(exporter trace.SpanExporter, exporterDesc string, res *resource.Resource, tp *trace.TracerProvider, sampler trace.Sampler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exporter = exporter
+	s.exporterDesc = exporterDesc
+	s.resource = res
+	s.tracerProvider = tp
+	s.samplerDesc = sampler.Description()
+}
+
+// current returns the TracerProvider currently receiving spans, so
+// setupOpenTelemetry's shutdown func always flushes the live provider even
+// after setSampler has swapped it out from under it.
+func (s *otelState) current() *trace.TracerProvider {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracerProvider
+}
+
+func (s *otelState) setSampler(sampler trace.Sampler) *trace.TracerProvider {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.tracerProvider
+
+	tp := trace.NewTracerProvider(
+		trace.WithBatcher(s.exporter),
+		trace.WithResource(s.resource),
+		trace.WithSampler(sampler),
+	)
+	s.tracerProvider = tp
+	s.samplerDesc = sampler.Description()
+
+	if old != nil {
+		// Flush and stop the batch processor we're replacing so it doesn't
+		// leak its goroutine or silently drop whatever it was holding.
+		if err := old.Shutdown(context.Background()); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"event": "FailedToShutdownReplacedTracerProvider",
+			}).Error(err)
+		}
+	}
+
+	return tp
+}
+
+// mountAdminRouter registers the opt-in /debug/observability sub-router
+// that lets an operator tune log level and trace sampling without a
+// redeploy. auth, if non-nil, runs before every admin route.
+func mountAdminRouter(app *fiber.App, auth fiber.Handler) {
+	admin := app.Group("/debug/observability")
+	if auth != nil {
+		admin.Use(auth)
+	}
+
+	admin.Get("/loglevel", getLogLevel)
+	admin.Put("/loglevel", putLogLevel)
+	admin.Get("/sampling", getSampling)
+	admin.Put("/sampling", putSampling)
+	admin.Get("/config", getConfig)
+}
+
+func getLogLevel(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"level": logrus.GetLevel().String()})
+}
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+func putLogLevel(c *fiber.Ctx) error {
+	var req logLevelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Validation("invalid request body").WithCause(err)
+	}
+
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		return Validation(fmt.Sprintf("unknown log level %q", req.Level)).WithCause(err)
+	}
+
+	logrus.SetLevel(level)
+	return c.JSON(fiber.Map{"level": level.String()})
+}
+
+func getSampling(c *fiber.Ctx) error {
+	globalOtelState.mu.Lock()
+	defer globalOtelState.mu.Unlock()
+	return c.JSON(fiber.Map{"sampler": globalOtelState.samplerDesc})
+}
+
+type samplingRequest struct {
+	// Mode is one of "always_on", "always_off" or "ratio".
+	Mode  string  `json:"mode"`
+	Ratio float64 `json:"ratio,omitempty"`
+}
+
+func putSampling(c *fiber.Ctx) error {
+	var req samplingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return Validation("invalid request body").WithCause(err)
+	}
+
+	var sampler trace.Sampler
+	switch req.Mode {
+	case "always_on":
+		sampler = AlwaysOnSampler()
+	case "always_off":
+		sampler = AlwaysOffSampler()
+	case "ratio":
+		sampler = RatioSampler(req.Ratio)
+	default:
+		return Validation(fmt.Sprintf("unknown sampling mode %q, want always_on, always_off or ratio", req.Mode))
+	}
+
+	tp := globalOtelState.setSampler(sampler)
+	otel.SetTracerProvider(tp)
+
+	return c.JSON(fiber.Map{"sampler": sampler.Description()})
+}
+
+func getConfig(c *fiber.Ctx) error {
+	globalOtelState.mu.Lock()
+	defer globalOtelState.mu.Unlock()
+
+	attrs := fiber.Map{}
+	if globalOtelState.resource != nil {
+		for _, kv := range globalOtelState.resource.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.AsInterface()
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"resource": attrs,
+		"exporter": globalOtelState.exporterDesc,
+		"sampler":  globalOtelState.samplerDesc,
+		"logLevel": logrus.GetLevel().String(),
+	})
+}
@@ -0,0 +1,82 @@
+package observability
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+)
+
+type ctxKey int
+
+const (
+	loggerCtxKey ctxKey = iota
+	requestIDCtxKey
+)
+
+// WithLogger attaches logger to ctx so it can be recovered with
+// LoggerFromContext by code that only has a context.Context, e.g. a
+// goroutine spawned off a request.
+func WithLogger(ctx context.Context, logger *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerCtxKey, logger)
+}
+
+// LoggerFromContext returns the logger attached by WithLogger, or a fresh
+// trace-aware logger built from ctx's span if none was attached.
+func LoggerFromContext(ctx context.Context) *logrus.Entry {
+	if logger, ok := ctx.Value(loggerCtxKey).(*logrus.Entry); ok {
+		return logger
+	}
+	return newTraceAwareLogrusLogger(ctx)
+}
+
+// withRequestID attaches the inbound request's X-Request-ID to ctx so
+// NewHTTPClient can propagate it to outgoing calls.
+func withRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey, requestID)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDCtxKey).(string)
+	return requestID
+}
+
+// NewHTTPClient returns an *http.Client whose transport propagates the
+// current trace context and baggage (via the propagator configured in
+// setupOpenTelemetry) and stamps outgoing requests with the inbound
+// request's X-Request-ID, so downstream services can correlate the call.
+func NewHTTPClient(ctx context.Context) *http.Client {
+	var transport http.RoundTripper = http.DefaultTransport
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		transport = &requestIDTransport{base: transport, requestID: requestID}
+	}
+
+	return &http.Client{
+		Transport: otelhttp.NewTransport(transport),
+	}
+}
+
+// requestIDTransport stamps every outgoing request with a fixed
+// X-Request-ID, mirroring the value the inbound request arrived with.
+type requestIDTransport struct {
+	base      http.RoundTripper
+	requestID string
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Request-ID", t.requestID)
+	return t.base.RoundTrip(req)
+}
+
+// NewGRPCDialOptions returns dial options that instrument outgoing gRPC
+// calls with the otelgrpc stats handler, so spans continue across the RPC
+// boundary the same way otelhttp does for HTTP.
+func NewGRPCDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+}
@@ -0,0 +1,222 @@
+package observability
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/compute/metadata"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+
+	texporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
+)
+
+// Config lets callers opt into non-default exporters, samplers, resource
+// attributes and propagators. NewLogrusAndTraceAwareFiberApp falls back to
+// the historical ENABLE_GCP_TRACING behaviour whenever no Config, or a zero
+// Config, is supplied.
+type Config struct {
+	// TraceExporters fan out every span to each configured exporter. When
+	// empty, the exporter is chosen the way it always has been: the GCP
+	// exporter when ENABLE_GCP_TRACING=true, otherwise OTLP/gRPC.
+	TraceExporters []TraceExporterConfig
+
+	// Sampler overrides the default sampler. When nil, the default is
+	// ParentBased(TraceIDRatioBased(0.01)) when a GCPTraceExporter is in use
+	// (configured here or picked by the ENABLE_GCP_TRACING fallback) and
+	// AlwaysSample otherwise.
+	Sampler trace.Sampler
+
+	// ResourceAttributes are merged into the detected resource attributes.
+	ResourceAttributes []attribute.KeyValue
+
+	// Propagators are combined into a composite TextMapPropagator. When
+	// empty, autoprop.NewTextMapPropagator() is used, as before.
+	Propagators []propagation.TextMapPropagator
+
+	// AdminAuth, if set, mounts a /debug/observability sub-router (log
+	// level and sampling can be tuned live) protected by this handler.
+	// Leave nil to keep the admin router disabled.
+	AdminAuth fiber.Handler
+}
+
+// TraceExporterConfig builds a trace.SpanExporter. Implementations are
+// value types so they can be constructed inline in a Config literal.
+type TraceExporterConfig interface {
+	NewSpanExporter(ctx context.Context) (trace.SpanExporter, error)
+}
+
+// GCPTraceExporter sends spans to Cloud Trace. ProjectID is resolved from
+// the GCP metadata server when empty, independent of ENABLE_GCP_TRACING, so
+// a caller opting into Config.TraceExporters doesn't need that legacy env
+// var set to get the right project ID.
+type GCPTraceExporter struct {
+	ProjectID string
+}
+
+func (e GCPTraceExporter) NewSpanExporter(ctx context.Context) (trace.SpanExporter, error) {
+	projectID, err := e.resolveProjectID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcp trace exporter: failed to resolve project ID from metadata server (set GCPTraceExporter.ProjectID to skip this lookup): %w", err)
+	}
+	return texporter.New(texporter.WithProjectID(projectID))
+}
+
+// resolveProjectID returns ProjectID as-is when set, otherwise looks it up
+// from the GCP metadata server. setupOpenTelemetry also calls this to point
+// the metrics exporter and Cloud Logging trace correlation at the same
+// project as the configured trace exporter.
+func (e GCPTraceExporter) resolveProjectID(ctx context.Context) (string, error) {
+	if e.ProjectID != "" {
+		return e.ProjectID, nil
+	}
+	return metadata.ProjectIDWithContext(ctx)
+}
+
+// OTLPGRPCExporter sends spans to an OTLP/gRPC collector, e.g. a self-hosted
+// Tempo or OTel collector. Endpoint defaults to the OTEL_EXPORTER_OTLP_*
+// environment variables when empty. When TLS is nil no credentials option is
+// set, so transport security is left to otlptracegrpc's own defaults and the
+// OTEL_EXPORTER_OTLP_* environment variables.
+type OTLPGRPCExporter struct {
+	Endpoint string
+	Headers  map[string]string
+	TLS      *tls.Config
+}
+
+func (e OTLPGRPCExporter) NewSpanExporter(ctx context.Context) (trace.SpanExporter, error) {
+	// A zero-value OTLPGRPCExporter behaves exactly like the exporter
+	// setupOpenTelemetry always built locally: no options, configured
+	// entirely through OTEL_EXPORTER_OTLP_* environment variables.
+	opts := []otlptracegrpc.Option{}
+	if e.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(e.Endpoint))
+	}
+	if len(e.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(e.Headers))
+	}
+	if e.TLS != nil {
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(e.TLS)))
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+// OTLPHTTPExporter sends spans to an OTLP/HTTP collector.
+type OTLPHTTPExporter struct {
+	Endpoint string
+	Headers  map[string]string
+	TLS      *tls.Config
+}
+
+func (e OTLPHTTPExporter) NewSpanExporter(ctx context.Context) (trace.SpanExporter, error) {
+	opts := []otlptracehttp.Option{}
+	if e.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(e.Endpoint))
+	}
+	if len(e.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(e.Headers))
+	}
+	if e.TLS != nil {
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(e.TLS))
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
+
+// StdoutExporter pretty-prints spans to stdout, useful for local debugging
+// without a collector running.
+type StdoutExporter struct{}
+
+func (StdoutExporter) NewSpanExporter(context.Context) (trace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithPrettyPrint())
+}
+
+// JaegerExporter sends spans to a Jaeger collector.
+type JaegerExporter struct {
+	Endpoint string
+}
+
+func (e JaegerExporter) NewSpanExporter(context.Context) (trace.SpanExporter, error) {
+	var opts []jaeger.CollectorEndpointOption
+	if e.Endpoint != "" {
+		opts = append(opts, jaeger.WithEndpoint(e.Endpoint))
+	}
+	return jaeger.New(jaeger.WithCollectorEndpoint(opts...))
+}
+
+// CustomExporter wraps a caller-supplied trace.SpanExporter so it can sit
+// alongside the built-in exporters in Config.TraceExporters.
+type CustomExporter struct {
+	Exporter trace.SpanExporter
+}
+
+func (e CustomExporter) NewSpanExporter(context.Context) (trace.SpanExporter, error) {
+	return e.Exporter, nil
+}
+
+// buildSpanExporter resolves cfg.TraceExporters into a single exporter,
+// fanning out to a multiSpanExporter when more than one is configured.
+func buildSpanExporter(ctx context.Context, cfgs []TraceExporterConfig) (trace.SpanExporter, error) {
+	exporters := make([]trace.SpanExporter, 0, len(cfgs))
+	for _, c := range cfgs {
+		exp, err := c.NewSpanExporter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, exp)
+	}
+
+	if len(exporters) == 1 {
+		return exporters[0], nil
+	}
+	return &multiSpanExporter{exporters: exporters}, nil
+}
+
+// describeExporters renders the configured exporters as a human-readable
+// summary for the admin /config endpoint, e.g. "GCPTraceExporter" or
+// "OTLPGRPCExporter,StdoutExporter".
+func describeExporters(cfgs []TraceExporterConfig) string {
+	names := make([]string, 0, len(cfgs))
+	for _, c := range cfgs {
+		names = append(names, reflect.TypeOf(c).Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// multiSpanExporter fans out every batch of spans to a set of underlying
+// exporters, so a service can, for example, send to Cloud Trace and a
+// self-hosted Tempo at the same time.
+type multiSpanExporter struct {
+	exporters []trace.SpanExporter
+}
+
+func (m *multiSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	var errs []error
+	for _, exporter := range m.exporters {
+		if err := exporter.ExportSpans(ctx, spans); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *multiSpanExporter) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range m.exporters {
+		if err := exporter.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
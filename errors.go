@@ -0,0 +1,192 @@
+package observability
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// problemTypeBase namespaces the "type" URIs handed out by the sentinel
+// constructors below, per RFC 7807 ("a URI reference that identifies the
+// problem type").
+const problemTypeBase = "https://errors.massiva-agora.dev/problems/"
+
+// CodedError is an error that knows how it should be rendered as a
+// RFC 7807 problem+json response. Handlers can return any error that
+// implements it and ProblemDetailErrorHandler takes care of the rest.
+type CodedError interface {
+	error
+	HTTPStatus() int
+	TypeURI() string
+	Title() string
+}
+
+// ExtensionsError is implemented by CodedErrors that want additional
+// members merged into the problem+json body, e.g. validation field errors.
+type ExtensionsError interface {
+	CodedError
+	Extensions() map[string]any
+}
+
+type codedError struct {
+	status     int
+	typeURI    string
+	title      string
+	detail     string
+	cause      error
+	extensions map[string]any
+}
+
+func newCodedError(status int, typeURI, title, detail string) *codedError {
+	return &codedError{status: status, typeURI: typeURI, title: title, detail: detail}
+}
+
+func (e *codedError) Error() string {
+	if e.cause != nil {
+		return e.detail + ": " + e.cause.Error()
+	}
+	return e.detail
+}
+
+func (e *codedError) Unwrap() error   { return e.cause }
+func (e *codedError) HTTPStatus() int { return e.status }
+func (e *codedError) TypeURI() string { return e.typeURI }
+func (e *codedError) Title() string   { return e.title }
+
+func (e *codedError) Extensions() map[string]any {
+	return e.extensions
+}
+
+// WithCause records the underlying error that caused this problem, so it's
+// preserved in logs and errors.Is/As chains without leaking into the
+// response body.
+func (e *codedError) WithCause(cause error) *codedError {
+	e.cause = cause
+	return e
+}
+
+// WithExtension attaches an additional member to be merged into the
+// problem+json body per RFC 7807's extension members.
+func (e *codedError) WithExtension(key string, value any) *codedError {
+	if e.extensions == nil {
+		e.extensions = map[string]any{}
+	}
+	e.extensions[key] = value
+	return e
+}
+
+// NotFound reports that the requested resource does not exist.
+func NotFound(detail string) *codedError {
+	return newCodedError(fiber.StatusNotFound, problemTypeBase+"not-found", "Not Found", detail)
+}
+
+// Conflict reports that the request could not be completed due to a
+// conflict with the current state of the resource.
+func Conflict(detail string) *codedError {
+	return newCodedError(fiber.StatusConflict, problemTypeBase+"conflict", "Conflict", detail)
+}
+
+// Validation reports that the request body or parameters failed validation.
+func Validation(detail string) *codedError {
+	return newCodedError(fiber.StatusUnprocessableEntity, problemTypeBase+"validation", "Validation Failed", detail)
+}
+
+// Unauthorized reports that the request lacks valid authentication.
+func Unauthorized(detail string) *codedError {
+	return newCodedError(fiber.StatusUnauthorized, problemTypeBase+"unauthorized", "Unauthorized", detail)
+}
+
+// Internal reports an unexpected server-side failure.
+func Internal(detail string) *codedError {
+	return newCodedError(fiber.StatusInternalServerError, problemTypeBase+"internal", "Internal Server Error", detail)
+}
+
+// Unavailable reports that a dependency is down or the service is
+// shedding load.
+func Unavailable(detail string) *codedError {
+	return newCodedError(fiber.StatusServiceUnavailable, problemTypeBase+"unavailable", "Service Unavailable", detail)
+}
+
+// TooManyRequests reports that the caller has exceeded a rate limit.
+func TooManyRequests(detail string) *codedError {
+	return newCodedError(fiber.StatusTooManyRequests, problemTypeBase+"too-many-requests", "Too Many Requests", detail)
+}
+
+// ProblemDetailErrorHandler is a fiber.Config.ErrorHandler that translates
+// any error returned from a handler into an application/problem+json
+// response, records it on the active span and logs it via GetLogger.
+// Install it with:
+//
+//	fiber.New(fiber.Config{ErrorHandler: observability.ProblemDetailErrorHandler})
+func ProblemDetailErrorHandler(c *fiber.Ctx, err error) error {
+	coded := toCodedError(err)
+
+	span := oteltrace.SpanFromContext(c.UserContext())
+	span.RecordError(err)
+	span.SetStatus(codes.Error, coded.Title())
+
+	logger := GetLogger(c)
+	if coded.HTTPStatus() >= fiber.StatusInternalServerError {
+		logger.Error(err)
+	} else {
+		logger.Warn(err)
+	}
+
+	detail := coded.Error()
+	if ce, ok := coded.(*codedError); ok {
+		// Keep the cause out of the response body; it was logged above.
+		detail = ce.detail
+	}
+
+	body := fiber.Map{
+		"type":     coded.TypeURI(),
+		"title":    coded.Title(),
+		"status":   coded.HTTPStatus(),
+		"detail":   detail,
+		"instance": c.Path(),
+	}
+
+	spanContext := span.SpanContext()
+	if spanContext.IsValid() {
+		body["traceId"] = spanContext.TraceID().String()
+	}
+
+	if ext, ok := coded.(ExtensionsError); ok {
+		for k, v := range ext.Extensions() {
+			body[k] = v
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "application/problem+json")
+	return c.Status(coded.HTTPStatus()).JSON(body)
+}
+
+// toCodedError adapts any error into a CodedError, defaulting fiber's own
+// *fiber.Error to its declared status and everything else to Internal.
+func toCodedError(err error) CodedError {
+	var coded CodedError
+	if errors.As(err, &coded) {
+		return coded
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		title := utils.StatusMessage(fiberErr.Code)
+		if title == "" {
+			title = fiber.ErrInternalServerError.Message
+		}
+		return newCodedError(fiberErr.Code, problemTypeBase+problemTypeSlug(title), title, fiberErr.Message)
+	}
+
+	return Internal(err.Error())
+}
+
+// problemTypeSlug turns an HTTP status message ("Not Found") into a
+// problemTypeBase-relative path segment ("not-found").
+func problemTypeSlug(title string) string {
+	return strings.ToLower(strings.ReplaceAll(title, " ", "-"))
+}
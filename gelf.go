@@ -0,0 +1,232 @@
+package observability
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	gelfMagicChunked  = "\x1e\x0f"
+	gelfChunkMaxSize  = 8154 // leave room for the 12-byte chunk header within a ~8KB UDP payload
+	gelfMaxChunkCount = 128
+)
+
+// gelfSeverity maps logrus levels to RFC 5424 syslog severities, as GELF's
+// "level" field expects.
+var gelfSeverity = map[logrus.Level]int{
+	logrus.PanicLevel: 0,
+	logrus.FatalLevel: 2,
+	logrus.ErrorLevel: 3,
+	logrus.WarnLevel:  4,
+	logrus.InfoLevel:  6,
+	logrus.DebugLevel: 7,
+	logrus.TraceLevel: 7,
+}
+
+// GelfHook is a logrus.Hook that ships GELF 1.1 messages to a Graylog input
+// over UDP (chunked when needed) or TCP (newline-delimited). Register it
+// with NewGelfHook only when GELF_ENDPOINT is configured, so services that
+// don't use Graylog see no change in behavior.
+type GelfHook struct {
+	proto string
+	host  string
+	conn  net.Conn
+}
+
+// NewGelfHook dials endpoint (host:port) over proto ("udp" or "tcp") and
+// returns a hook ready to be added via logrus.AddHook.
+func NewGelfHook(endpoint, proto string) (*GelfHook, error) {
+	if proto != "udp" && proto != "tcp" {
+		return nil, fmt.Errorf("gelf: unsupported protocol %q, want \"udp\" or \"tcp\"", proto)
+	}
+
+	conn, err := net.Dial(proto, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("gelf: failed to dial %s://%s: %w", proto, endpoint, err)
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return &GelfHook{proto: proto, host: host, conn: conn}, nil
+}
+
+// maybeAddGelfHook registers a GelfHook on logrus's standard logger when
+// GELF_ENDPOINT is set, defaulting GELF_PROTO to "udp".
+func maybeAddGelfHook() {
+	endpoint := os.Getenv("GELF_ENDPOINT")
+	if endpoint == "" {
+		return
+	}
+
+	proto := os.Getenv("GELF_PROTO")
+	if proto == "" {
+		proto = "udp"
+	}
+
+	hook, err := NewGelfHook(endpoint, proto)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"event": "FailedToSetupGelfHook",
+		}).Error(err)
+		return
+	}
+	logrus.AddHook(hook)
+}
+
+func (h *GelfHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *GelfHook) Fire(entry *logrus.Entry) error {
+	msg, err := h.buildMessage(entry)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("gelf: failed to marshal message: %w", err)
+	}
+
+	if h.proto == "tcp" {
+		payload = append(payload, '\n')
+		_, err := h.conn.Write(payload)
+		return err
+	}
+
+	return h.writeUDP(payload)
+}
+
+// gelfMessage is the GELF 1.1 payload shape. Additional fields (trace/span
+// IDs already injected by newTraceAwareLogrusLogger, plus any caller fields)
+// are merged in as "_"-prefixed members via MarshalJSON.
+type gelfMessage struct {
+	Version      string  `json:"version"`
+	Host         string  `json:"host"`
+	ShortMessage string  `json:"short_message"`
+	FullMessage  string  `json:"full_message,omitempty"`
+	Timestamp    float64 `json:"timestamp"`
+	Level        int     `json:"level"`
+	Extra        logrus.Fields
+}
+
+func (m gelfMessage) MarshalJSON() ([]byte, error) {
+	fields := map[string]interface{}{
+		"version":       m.Version,
+		"host":          m.Host,
+		"short_message": m.ShortMessage,
+		"timestamp":     m.Timestamp,
+		"level":         m.Level,
+	}
+	if m.FullMessage != "" {
+		fields["full_message"] = m.FullMessage
+	}
+	for k, v := range m.Extra {
+		fields["_"+k] = v
+	}
+	return json.Marshal(fields)
+}
+
+func (h *GelfHook) buildMessage(entry *logrus.Entry) (gelfMessage, error) {
+	severity, ok := gelfSeverity[entry.Level]
+	if !ok {
+		severity = 6
+	}
+
+	return gelfMessage{
+		Version:      "1.1",
+		Host:         h.host,
+		ShortMessage: entry.Message,
+		FullMessage:  fullMessage(entry),
+		Timestamp:    float64(entry.Time.UnixNano()) / float64(time.Second),
+		Level:        severity,
+		Extra:        entry.Data,
+	}, nil
+}
+
+// fullMessage renders entry.Message plus its fields, one per line, so
+// full_message carries the same detail an operator would see reading the
+// JSON log line directly.
+func fullMessage(entry *logrus.Entry) string {
+	if len(entry.Data) == 0 {
+		return entry.Message
+	}
+
+	fields := make([]string, 0, len(entry.Data))
+	for k, v := range entry.Data {
+		fields = append(fields, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(fields)
+
+	return entry.Message + "\n" + strings.Join(fields, "\n")
+}
+
+// writeUDP sends payload as a single datagram, or as GELF chunks when it
+// exceeds gelfChunkMaxSize.
+func (h *GelfHook) writeUDP(payload []byte) error {
+	if len(payload) <= gelfChunkMaxSize {
+		_, err := h.conn.Write(payload)
+		return err
+	}
+
+	compressed, err := gzipCompress(payload)
+	if err != nil {
+		return fmt.Errorf("gelf: failed to compress oversized message: %w", err)
+	}
+	payload = compressed
+
+	total := (len(payload) + gelfChunkMaxSize - 1) / gelfChunkMaxSize
+	if total > gelfMaxChunkCount {
+		return fmt.Errorf("gelf: message too large (%d chunks, max %d)", total, gelfMaxChunkCount)
+	}
+
+	msgID := make([]byte, 8)
+	if _, err := rand.Read(msgID); err != nil {
+		return fmt.Errorf("gelf: failed to generate message id: %w", err)
+	}
+
+	for i := 0; i < total; i++ {
+		start := i * gelfChunkMaxSize
+		end := start + gelfChunkMaxSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+
+		var chunk bytes.Buffer
+		chunk.WriteString(gelfMagicChunked)
+		chunk.Write(msgID)
+		chunk.WriteByte(byte(i))
+		chunk.WriteByte(byte(total))
+		chunk.Write(payload[start:end])
+
+		if _, err := h.conn.Write(chunk.Bytes()); err != nil {
+			return fmt.Errorf("gelf: failed to write chunk %d/%d: %w", i+1, total, err)
+		}
+	}
+	return nil
+}
+
+func gzipCompress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
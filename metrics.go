@@ -0,0 +1,177 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"runtime/metrics"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	mexporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/metric"
+)
+
+const meterName = "github.com/massiva-agora/observability"
+
+// setupOpenTelemetryMetrics mirrors setupOpenTelemetry: it wires up a
+// MeterProvider using the GCP monitoring exporter when gcpMode is set (a
+// GCPTraceExporter is configured, or the legacy ENABLE_GCP_TRACING fallback
+// picked one), or a periodic OTLP/gRPC push locally. GCPProjectID is used for
+// the monitoring exporter's project, kept in sync with the configured
+// GCPTraceExporter by setupOpenTelemetry.
+func setupOpenTelemetryMetrics(ctx context.Context, res *resource.Resource, gcpMode bool) (shutdown func(context.Context) error, err error) {
+	var reader sdkmetric.Reader
+
+	if gcpMode {
+		metricExporter, err := mexporter.New(mexporter.WithProjectID(GCPProjectID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+		}
+		reader = sdkmetric.NewPeriodicReader(metricExporter)
+	} else {
+		metricExporter, err := otlpmetricgrpc.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+		}
+		reader = sdkmetric.NewPeriodicReader(metricExporter)
+	}
+
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetMeterProvider(meterProvider)
+
+	if err := startRuntimeMetrics(meterProvider); err != nil {
+		return nil, fmt.Errorf("failed to start runtime metrics: %w", err)
+	}
+
+	return meterProvider.Shutdown, nil
+}
+
+// GetMeter returns a Meter that users can use to record their own counters
+// and histograms alongside the RED metrics this package records
+// automatically.
+func GetMeter(name string) metric.Meter {
+	return otel.GetMeterProvider().Meter(name)
+}
+
+// runtimeMetricSamples are the runtime/metrics descriptors startRuntimeMetrics
+// reads on every collection. Keeping them in one slice lets metrics.Read fill
+// them all in a single call instead of one lookup per sample.
+var runtimeMetricSamples = []metrics.Sample{
+	{Name: "/sched/goroutines:goroutines"},
+	{Name: "/memory/classes/heap/objects:bytes"},
+	{Name: "/gc/cycles/total:gc-cycles"},
+}
+
+// startRuntimeMetrics records goroutine and GC/heap statistics on every
+// collection so services get baseline resource visibility for free.
+func startRuntimeMetrics(mp *sdkmetric.MeterProvider) error {
+	meter := mp.Meter(meterName)
+
+	goroutines, err := meter.Int64ObservableGauge(
+		"process.runtime.go.goroutines",
+		metric.WithDescription("Number of goroutines that currently exist"),
+	)
+	if err != nil {
+		return err
+	}
+
+	heapAlloc, err := meter.Int64ObservableGauge(
+		"process.runtime.go.mem.heap_alloc",
+		metric.WithUnit("By"),
+		metric.WithDescription("Bytes of allocated heap objects"),
+	)
+	if err != nil {
+		return err
+	}
+
+	gcCount, err := meter.Int64ObservableCounter(
+		"process.runtime.go.gc.count",
+		metric.WithDescription("Number of completed GC cycles"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(
+		func(_ context.Context, o metric.Observer) error {
+			metrics.Read(runtimeMetricSamples)
+
+			o.ObserveInt64(goroutines, int64(runtimeMetricSamples[0].Value.Uint64()))
+			o.ObserveInt64(heapAlloc, int64(runtimeMetricSamples[1].Value.Uint64()))
+			o.ObserveInt64(gcCount, int64(runtimeMetricSamples[2].Value.Uint64()))
+			return nil
+		},
+		goroutines, heapAlloc, gcCount,
+	)
+	return err
+}
+
+// httpMetrics holds the RED-style HTTP instruments recorded by
+// MetricsMiddleware.
+type httpMetrics struct {
+	requests metric.Int64Counter
+	duration metric.Float64Histogram
+	inFlight metric.Int64UpDownCounter
+}
+
+func newHTTPMetrics(meter metric.Meter) (*httpMetrics, error) {
+	requests, err := meter.Int64Counter(
+		"http.server.requests",
+		metric.WithDescription("Number of HTTP requests handled"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram(
+		"http.server.duration",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Duration of HTTP requests"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inFlight, err := meter.Int64UpDownCounter(
+		"http.server.active_requests",
+		metric.WithDescription("Number of in-flight HTTP requests"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpMetrics{requests: requests, duration: duration, inFlight: inFlight}, nil
+}
+
+// MetricsMiddleware records RED-style HTTP metrics (request count, request
+// duration and in-flight requests) for every request. It is registered
+// automatically by NewLogrusAndTraceAwareFiberApp.
+func MetricsMiddleware() fiber.Handler {
+	m, err := newHTTPMetrics(GetMeter(meterName))
+	if err != nil {
+		panic(fmt.Errorf("failed to create HTTP metrics: %w", err))
+	}
+
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		m.inFlight.Add(ctx, 1)
+		start := time.Now()
+
+		err := c.Next()
+
+		m.inFlight.Add(ctx, -1)
+		m.duration.Record(ctx, float64(time.Since(start).Milliseconds()))
+		m.requests.Add(ctx, 1)
+
+		return err
+	}
+}
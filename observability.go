@@ -10,23 +10,22 @@ import (
 
 	"github.com/gofiber/contrib/otelfiber"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/mikhail-bigun/fiberlogrus"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/trace"
 	oteltrace "go.opentelemetry.io/otel/trace"
 
-	texporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
 	"go.opentelemetry.io/contrib/detectors/gcp"
 	"go.opentelemetry.io/contrib/propagators/autoprop"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 )
 
 func getGcpProjectID() string {
 	projectID := "local"
-	var err error
 	if os.Getenv("ENABLE_GCP_TRACING") == "true" {
 		projectID, _ = metadata.ProjectIDWithContext(context.Background())
 	}
@@ -37,25 +36,41 @@ func getGcpProjectID() string {
 // for any GCP deployments.
 var GCPProjectID = getGcpProjectID()
 
-func NewLogrusAndTraceAwareFiberApp(ctx context.Context, serviceName string) (*fiber.App, func(context.Context) error) {
+// NewLogrusAndTraceAwareFiberApp wires up a Fiber app with trace-aware
+// logging and OpenTelemetry tracing and metrics. cfg is optional: with none
+// supplied, the historical ENABLE_GCP_TRACING behaviour is preserved.
+func NewLogrusAndTraceAwareFiberApp(ctx context.Context, serviceName string, cfg ...Config) (*fiber.App, func(context.Context) error) {
 	logrus.SetOutput(os.Stdout)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
+	maybeAddGelfHook()
+
+	var config Config
+	if len(cfg) > 0 {
+		config = cfg[0]
+	}
 
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
+		ErrorHandler:          ProblemDetailErrorHandler,
 	})
 
-	app.Use(fiberlogrus.New())
-	app.Use(otelfiber.Middleware())
-	app.Use(newTraceAwareLogrusLoggerMiddleware())
-
-	shutdown, err := setupOpenTelemetry(ctx, serviceName)
+	shutdown, err := setupOpenTelemetry(ctx, serviceName, config)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"event": "FailedToSetupOpenTelemetry",
 		}).Fatal(err)
 	}
 
+	app.Use(fiberlogrus.New())
+	app.Use(otelfiber.Middleware())
+	app.Use(requestid.New())
+	app.Use(newTraceAwareLogrusLoggerMiddleware())
+	app.Use(MetricsMiddleware())
+
+	if config.AdminAuth != nil {
+		mountAdminRouter(app, config.AdminAuth)
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"event": "StartUp",
 	}).Info()
@@ -75,7 +90,10 @@ func SafeShutdown(errs ...error) {
 func newTraceAwareLogrusLogger(ctx context.Context) *logrus.Entry {
 	span := oteltrace.SpanFromContext(ctx)
 	spanContext := span.SpanContext()
-	logger := logrus.NewEntry(logrus.New())
+	// Built off the standard logger so this still goes through the JSON
+	// formatter, the GELF hook and the admin /loglevel toggle even when
+	// there's no span to attach (e.g. a detached background goroutine).
+	logger := logrus.NewEntry(logrus.StandardLogger())
 
 	if spanContext.IsValid() {
 		traceId := "projects/" + GCPProjectID + "/traces/" + spanContext.TraceID().String()
@@ -90,7 +108,15 @@ func newTraceAwareLogrusLogger(ctx context.Context) *logrus.Entry {
 
 func newTraceAwareLogrusLoggerMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		c.Locals("logger", newTraceAwareLogrusLogger(c.UserContext()))
+		logger := newTraceAwareLogrusLogger(c.UserContext())
+		c.Locals("logger", logger)
+
+		ctx := WithLogger(c.UserContext(), logger)
+		if requestID, ok := c.Locals(requestid.ConfigDefault.ContextKey).(string); ok {
+			ctx = withRequestID(ctx, requestID)
+		}
+		c.SetUserContext(ctx)
+
 		return c.Next()
 	}
 }
@@ -100,65 +126,114 @@ func GetLogger(c *fiber.Ctx) *logrus.Entry {
 		return logger
 	}
 
-	return logrus.NewEntry(logrus.New())
+	return logrus.NewEntry(logrus.StandardLogger())
 }
 
-func setupOpenTelemetry(ctx context.Context, serviceName string) (shutdown func(context.Context) error, err error) {
-	var res *resource.Resource
-	var traceExporter trace.SpanExporter
-	var tracerProvider *trace.TracerProvider
+// usesGCPTraceExporter reports whether the resolved exporter set actually
+// includes a GCPTraceExporter, whether the caller configured it explicitly
+// via Config.TraceExporters or it was picked by the ENABLE_GCP_TRACING
+// fallback below. GCP-specific defaults (resource detector, sampler,
+// project ID) key off this rather than off "no exporters were configured",
+// so a caller using the composable API with GCPTraceExporter still gets
+// them without also having to set the legacy env var.
+func usesGCPTraceExporter(exporterConfigs []TraceExporterConfig) bool {
+	_, ok := firstGCPTraceExporter(exporterConfigs)
+	return ok
+}
 
-	if os.Getenv("ENABLE_GCP_TRACING") == "true" {
-		traceExporter, err = texporter.New(texporter.WithProjectID(GCPProjectID))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create resource: %w", err)
+// firstGCPTraceExporter returns the first configured GCPTraceExporter, if
+// any, so its project ID can be reused for metrics and Cloud Logging trace
+// correlation instead of re-deriving it from ENABLE_GCP_TRACING.
+func firstGCPTraceExporter(exporterConfigs []TraceExporterConfig) (GCPTraceExporter, bool) {
+	for _, e := range exporterConfigs {
+		if gcp, ok := e.(GCPTraceExporter); ok {
+			return gcp, true
 		}
+	}
+	return GCPTraceExporter{}, false
+}
 
-		res, err = resource.New(ctx,
-			resource.WithDetectors(gcp.NewDetector()),
-			resource.WithTelemetrySDK(),
-			resource.WithAttributes(
-				semconv.ServiceNameKey.String(serviceName),
-			),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create resource: %w", err)
+func setupOpenTelemetry(ctx context.Context, serviceName string, cfg Config) (shutdown func(context.Context) error, err error) {
+	exporterConfigs := cfg.TraceExporters
+	if len(exporterConfigs) == 0 {
+		if os.Getenv("ENABLE_GCP_TRACING") == "true" {
+			exporterConfigs = []TraceExporterConfig{GCPTraceExporter{}}
+		} else {
+			exporterConfigs = []TraceExporterConfig{OTLPGRPCExporter{}}
 		}
-		tracerProvider = trace.NewTracerProvider(
-			trace.WithBatcher(traceExporter),
-			trace.WithResource(res),
-			// In prod, sample based on whether the parent trace is sampled, or
-			// default to 1% of traces.
-			trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(0.01))),
-		)
-	} else {
-		res, err = resource.New(ctx,
-			resource.WithAttributes(
-				semconv.ServiceNameKey.String(serviceName),
-			),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+	gcpExporter, gcpMode := firstGCPTraceExporter(exporterConfigs)
+	if gcpMode {
+		// Keep GCPProjectID (used for Cloud Logging trace correlation and
+		// below for the metrics exporter) pointed at whatever project the
+		// trace exporter is actually configured to use, rather than relying
+		// on the getGcpProjectID metadata lookup done at package init.
+		if projectID, err := gcpExporter.resolveProjectID(ctx); err == nil {
+			GCPProjectID = projectID
 		}
+	}
 
-		traceExporter, err = otlptracegrpc.New(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
-		}
-		tracerProvider = trace.NewTracerProvider(
-			trace.WithBatcher(traceExporter),
-			trace.WithResource(res),
+	traceExporter, err := buildSpanExporter(ctx, exporterConfigs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	resourceOpts := []resource.Option{
+		resource.WithAttributes(semconv.ServiceNameKey.String(serviceName)),
+	}
+	if len(cfg.ResourceAttributes) > 0 {
+		resourceOpts = append(resourceOpts, resource.WithAttributes(cfg.ResourceAttributes...))
+	}
+	if gcpMode {
+		resourceOpts = append(resourceOpts, resource.WithDetectors(gcp.NewDetector()), resource.WithTelemetrySDK())
+	}
+
+	res, err := resource.New(ctx, resourceOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	sampler := cfg.Sampler
+	if sampler == nil {
+		if gcpMode {
+			// In prod, sample based on whether the parent trace is sampled, or
+			// default to 1% of traces.
+			sampler = trace.ParentBased(trace.TraceIDRatioBased(0.01))
+		} else {
 			// Locally, sample every request so it's easy to debug.
-			trace.WithSampler(trace.AlwaysSample()),
-		)
+			sampler = trace.AlwaysSample()
+		}
 	}
 
-	otel.SetTextMapPropagator(autoprop.NewTextMapPropagator())
+	tracerProvider := trace.NewTracerProvider(
+		trace.WithBatcher(traceExporter),
+		trace.WithResource(res),
+		trace.WithSampler(sampler),
+	)
+	globalOtelState.set(traceExporter, describeExporters(exporterConfigs), res, tracerProvider, sampler)
+
+	propagator := autoprop.NewTextMapPropagator()
+	if len(cfg.Propagators) > 0 {
+		propagator = propagation.NewCompositeTextMapPropagator(cfg.Propagators...)
+	}
+	otel.SetTextMapPropagator(propagator)
 	otel.SetTracerProvider(tracerProvider)
 
+	shutdownMetrics, err := setupOpenTelemetryMetrics(ctx, res, gcpMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up metrics: %w", err)
+	}
+
 	shutdown = func(ctx context.Context) error {
 		var errs []error
-		if err := tracerProvider.Shutdown(ctx); err != nil {
+		// Read the live provider rather than closing over tracerProvider: an
+		// admin sampling change replaces it in globalOtelState, and shutting
+		// down the stale local would leave the real one's buffered spans
+		// unflushed.
+		if err := globalOtelState.current().Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		if err := shutdownMetrics(ctx); err != nil {
 			errs = append(errs, err)
 		}
 		if len(errs) > 0 {
@@ -0,0 +1,102 @@
+package observability
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// AlwaysOnSampler samples every span.
+func AlwaysOnSampler() trace.Sampler {
+	return trace.AlwaysSample()
+}
+
+// AlwaysOffSampler samples no spans.
+func AlwaysOffSampler() trace.Sampler {
+	return trace.NeverSample()
+}
+
+// RatioSampler samples a fixed fraction of traces, ignoring the parent's
+// sampling decision.
+func RatioSampler(ratio float64) trace.Sampler {
+	return trace.TraceIDRatioBased(ratio)
+}
+
+// ParentBasedSampler respects the parent span's sampling decision, falling
+// back to root for spans with no parent.
+func ParentBasedSampler(root trace.Sampler) trace.Sampler {
+	return trace.ParentBased(root)
+}
+
+// RateLimitedSampler admits at most maxPerSecond spans per second,
+// regardless of trace ID or parent: every span's sampling decision, child
+// spans included, takes a token. It's useful for capping trace volume
+// from a bursty or high-QPS service without the all-or-nothing behaviour of
+// a ratio sampler.
+func RateLimitedSampler(maxPerSecond float64) trace.Sampler {
+	return &rateLimitedSampler{
+		limiter: newTokenBucket(maxPerSecond),
+	}
+}
+
+type rateLimitedSampler struct {
+	limiter *tokenBucket
+}
+
+func (s *rateLimitedSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	decision := trace.Drop
+	if s.limiter.Allow() {
+		decision = trace.RecordAndSample
+	}
+	return trace.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace.ParentBased(trace.AlwaysSample()).ShouldSample(p).Tracestate,
+	}
+}
+
+func (s *rateLimitedSampler) Description() string {
+	return "RateLimitedSampler"
+}
+
+// tokenBucket is a minimal thread-safe token bucket used to cap the rate of
+// sampling decisions without pulling in an extra dependency.
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	maxTokens  float64
+	refillRate float64 // tokens per second
+	lastRefill time.Time
+}
+
+func newTokenBucket(ratePerSecond float64) *tokenBucket {
+	return &tokenBucket{
+		tokens:     ratePerSecond,
+		maxTokens:  ratePerSecond,
+		refillRate: ratePerSecond,
+		lastRefill: time.Now(),
+	}
+}
+
+func (b *tokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+	b.tokens = min(b.maxTokens, b.tokens+elapsed*b.refillRate)
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func min(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,24 @@
+package observability
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationError flattens a go-playground/validator error into a
+// CodedError whose "errors" extension member maps each offending field's
+// namespace to the validation tag it failed (e.g. "required"), ready to hand
+// back through
+// ProblemDetailErrorHandler.
+func ValidationError(err error) CodedError {
+	fieldErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return Validation(err.Error())
+	}
+
+	fields := make(map[string]string, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		fields[fe.Namespace()] = fe.ActualTag()
+	}
+
+	return Validation("request failed validation").WithExtension("errors", fields)
+}